@@ -19,32 +19,51 @@ limitations under the License.
 package e2e_test
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"testing"
-	"time"
 
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
 	"github.com/onsi/ginkgo/reporters"
 	. "github.com/onsi/gomega"
 
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	bootstrapv1 "sigs.k8s.io/cluster-api-bootstrap-provider-kubeadm/api/v1alpha2"
 	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha2"
-	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/auth"
-	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework"
-	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/management/kind"
-	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/generators"
 	capiv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/logcollector"
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/management"
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/management/existing"
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/management/kind"
+)
+
+// providerInventoryGVK is the GroupVersionKind clusterctl records its
+// installed-provider inventory under.
+var providerInventoryGVK = schema.GroupVersionKind{
+	Group:   "clusterctl.cluster.x-k8s.io",
+	Version: "v1alpha3",
+	Kind:    "Provider",
+}
+
+// TODO Parameterize some of these variables
+const (
+	kubernetesVersion = "v1.16.2"
+
+	// managementBackendEnvVar selects which management.Cluster
+	// implementation BeforeSuite uses: "kind" (default), "existing", or
+	// the not-yet-implemented "aks".
+	managementBackendEnvVar = "E2E_MANAGEMENT_BACKEND"
 )
 
 func TestE2E(t *testing.T) {
@@ -58,134 +77,139 @@ func TestE2E(t *testing.T) {
 }
 
 var (
-	ctx   = context.Background()
-	creds auth.Creds
-	mgmt  *kind.Cluster
-
-	// TODO Parameterize some of these variables
-	location       = "westus2"
-	vmSize         = "Standard_B2ms"
-	namespace      = "default"
-	k8sVersion     = "v1.16.2"
-	imageOffer     = "capi"
-	imagePublisher = "cncf-upstream"
-	imageSKU       = "k8s-1dot16-ubuntu-1804"
-	imageVersion   = "latest"
-)
+	ctx = context.Background()
 
-var _ = BeforeSuite(func() {
-	var err error
+	// e2eConfig carries the providers, images, variables and intervals
+	// loaded from test/e2e/config/azure.yaml.
+	e2eConfig *clusterctl.E2EConfig
 
-	By("Loading Azure credentials")
-	if credsFile, found := os.LookupEnv("AZURE_CREDENTIALS"); found {
-		creds, err = auth.LoadFromFile(credsFile)
-	} else {
-		creds, err = auth.LoadFromEnvironment()
-	}
-	Expect(err).NotTo(HaveOccurred())
-	Expect(creds).NotTo(BeNil())
-	Expect(creds.TenantID).NotTo(BeEmpty())
-	Expect(creds.SubscriptionID).NotTo(BeEmpty())
-	Expect(creds.ClientID).NotTo(BeEmpty())
-	Expect(creds.ClientSecret).NotTo(BeEmpty())
+	// clusterctlConfigPath is the local clusterctl repository generated
+	// from e2eConfig, passed to every clusterctl invocation in the suite.
+	clusterctlConfigPath string
 
-	By("Creating management cluster")
-	scheme := runtime.NewScheme()
-	Expect(appsv1.AddToScheme(scheme)).To(Succeed())
-	Expect(corev1.AddToScheme(scheme)).To(Succeed())
-	Expect(capiv1.AddToScheme(scheme)).To(Succeed())
-	Expect(bootstrapv1.AddToScheme(scheme)).To(Succeed())
-	Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	artifactFolder string
 
-	managerImage, found := os.LookupEnv("MANAGER_IMAGE")
-	Expect(found).To(BeTrue(), fmt.Sprint("MANAGER_IMAGE not set"))
+	// mgmtCluster is the management.Cluster backend selected via
+	// E2E_MANAGEMENT_BACKEND: a disposable kind cluster by default, or a
+	// long-lived cluster the developer already has running.
+	mgmtCluster           management.Cluster
+	bootstrapClusterProxy framework.ClusterProxy
 
-	mgmt, err = kind.NewCluster(ctx, "mgmt", scheme, managerImage)
-	Expect(err).NotTo(HaveOccurred())
-	Expect(mgmt).NotTo(BeNil())
-
-	// TODO Figure out how to keep these versions in sync across the code base
-	capi := &generators.ClusterAPI{Version: "v0.2.7"}
-	cabpk := &generators.Bootstrap{Version: "v0.1.5"}
-	infra := &generators.Infra{Creds: creds}
-
-	framework.InstallComponents(ctx, mgmt, capi, cabpk, infra)
-
-	// DO NOT stream "capi-controller-manager" logs as it prints out azure.json
-	// go func() {
-	// 	defer GinkgoRecover()
-	// 	watchDeployment(mgmt, "cabpk-system", "cabpk-controller-manager")
-	// }()
-	// go func() {
-	// 	defer GinkgoRecover()
-	// 	watchDeployment(mgmt, "capz-system", "capz-controller-manager")
-	// }()
-})
+	// managementBackend is the resolved value of managementBackendEnvVar,
+	// recorded so AfterSuite can tell whether bootstrapClusterProxy points
+	// at a cluster this suite owns.
+	managementBackend string
+)
 
-var _ = AfterSuite(func() {
-	By("Tearing down management cluster")
-	Expect(mgmt.Teardown(ctx)).NotTo(HaveOccurred())
-})
+var _ = BeforeSuite(func() {
+	var err error
 
-func watchDeployment(mgmt *kind.Cluster, namespace, name string) {
-	artifactPath, _ := os.LookupEnv("ARTIFACTS")
-	logDir := path.Join(artifactPath, "logs")
+	artifactFolder, _ = os.LookupEnv("ARTIFACTS")
+	Expect(artifactFolder).NotTo(BeEmpty(), "the ARTIFACTS environment variable must be set")
 
-	c, err := mgmt.GetClient()
-	Expect(err).NotTo(HaveOccurred())
+	By("Loading the e2e test configuration")
+	e2eConfig = clusterctl.LoadE2EConfig(ctx, clusterctl.LoadE2EConfigInput{
+		ConfigPath: filepath.Join("config", "azure.yaml"),
+	})
+	Expect(e2eConfig).NotTo(BeNil())
 
-	waitDeployment(c, namespace, name)
+	By("Creating a clusterctl local repository from the e2e configuration")
+	clusterctlConfigPath = clusterctl.CreateRepository(ctx, clusterctl.CreateRepositoryInput{
+		E2EConfig:        e2eConfig,
+		RepositoryFolder: filepath.Join(artifactFolder, "repository"),
+	})
 
-	deployment := &appsv1.Deployment{}
-	deploymentKey := client.ObjectKey{Namespace: namespace, Name: name}
-	Expect(c.Get(ctx, deploymentKey, deployment)).To(Succeed())
+	managementBackend = os.Getenv(managementBackendEnvVar)
+	if managementBackend == "" {
+		managementBackend = "kind"
+	}
 
-	selector, err := metav1.LabelSelectorAsMap(deployment.Spec.Selector)
+	By(fmt.Sprintf("Getting a %q management cluster", managementBackend))
+	switch managementBackend {
+	case "kind":
+		mgmtCluster, err = kind.NewCluster(ctx, e2eConfig.ManagementClusterName, e2eConfig.Images)
+	case "existing":
+		mgmtCluster, err = existing.NewCluster()
+	default:
+		err = fmt.Errorf("unsupported %s %q, must be one of: kind, existing", managementBackendEnvVar, managementBackend)
+	}
 	Expect(err).NotTo(HaveOccurred())
+	Expect(mgmtCluster).NotTo(BeNil())
 
-	pods := &corev1.PodList{}
-	Expect(c.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels(selector))).To(Succeed())
-
-	for _, pod := range pods.Items {
-		for _, container := range deployment.Spec.Template.Spec.Containers {
-			if container.Name != "manager" {
-				continue
-			}
-			logFile := path.Join(logDir, name, pod.Name, container.Name+".log")
-			Expect(os.MkdirAll(filepath.Dir(logFile), 0755)).To(Succeed())
-
-			clientSet, err := mgmt.Clientset()
-			Expect(err).NotTo(HaveOccurred())
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(capiv1.AddToScheme(scheme)).To(Succeed())
+	Expect(bootstrapv1.AddToScheme(scheme)).To(Succeed())
+	Expect(infrav1.AddToScheme(scheme)).To(Succeed())
 
-			opts := &corev1.PodLogOptions{Container: container.Name, Follow: true}
-			logsStream, err := clientSet.CoreV1().Pods(namespace).GetLogs(pod.Name, opts).Stream()
-			Expect(err).NotTo(HaveOccurred())
-			defer logsStream.Close()
+	bootstrapClusterProxy = framework.NewClusterProxy("mgmt", mgmtCluster.GetKubeconfigPath(), scheme)
+	Expect(bootstrapClusterProxy).NotTo(BeNil())
 
-			f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			Expect(err).NotTo(HaveOccurred())
-			defer f.Close()
+	wantAzureVersion := mustGetProviderVersion(e2eConfig, "azure")
+	if installedVersion, ok := installedProviderVersion(bootstrapClusterProxy, "infrastructure-azure"); ok && installedVersion == wantAzureVersion {
+		By(fmt.Sprintf("Skipping component install: CAPZ %s is already installed on the management cluster", installedVersion))
+	} else {
+		By("Initializing the management cluster with CAPI, CABPK and CAPZ")
+		clusterctl.InitManagementCluster(ctx, clusterctl.InitManagementClusterInput{
+			ClusterProxy:            bootstrapClusterProxy,
+			ClusterctlConfigPath:    clusterctlConfigPath,
+			InfrastructureProviders: e2eConfig.InfrastructureProviders(),
+		}, e2eConfig.GetIntervals("default", "wait-controllers")...)
+	}
 
-			out := bufio.NewWriter(f)
-			defer out.Flush()
+	By("Streaming controller logs")
+	logcollector.CollectInfrastructureLogs(ctx, bootstrapClusterProxy, artifactFolder)
+})
 
-			_, err = out.ReadFrom(logsStream)
-			if err != nil && err.Error() != "unexpected EOF" {
-				Expect(err).NotTo(HaveOccurred())
-			}
-		}
+// installedProviderVersion reads the version clusterctl recorded for
+// providerName (e.g. "infrastructure-azure") in its inventory on mgmt,
+// so BeforeSuite can tell an up-to-date existing management cluster from
+// a stale one instead of only checking that the provider's CRDs exist.
+func installedProviderVersion(mgmt framework.ClusterProxy, providerName string) (string, bool) {
+	provider := &unstructured.Unstructured{}
+	provider.SetGroupVersionKind(providerInventoryGVK)
+	key := client.ObjectKey{Namespace: "capz-system", Name: providerName}
+	if err := mgmt.GetClient().Get(ctx, key, provider); err != nil {
+		return "", false
+	}
+	version, found, err := unstructured.NestedString(provider.Object, "version")
+	if err != nil || !found {
+		return "", false
 	}
+	return version, true
 }
 
-func waitDeployment(c client.Client, namespace, name string) {
-	Eventually(func() (int32, error) {
-		deployment := &appsv1.Deployment{}
-		deploymentKey := client.ObjectKey{Namespace: namespace, Name: name}
-		if err := c.Get(context.TODO(), deploymentKey, deployment); err != nil {
-			return 0, err
+// mustGetProviderVersion returns the version e2eConfig pins for the
+// named provider, failing the spec immediately if it isn't configured.
+func mustGetProviderVersion(cfg *clusterctl.E2EConfig, providerName string) string {
+	for _, provider := range cfg.Providers {
+		if provider.Name != providerName {
+			continue
 		}
-		return deployment.Status.ReadyReplicas, nil
-	}, 5*time.Minute, 15*time.Second,
-		fmt.Sprintf("Deployment %s/%s could not reach the ready state", namespace, name),
-	).ShouldNot(BeZero())
+		Expect(provider.Versions).NotTo(BeEmpty(), "provider %q has no versions configured", providerName)
+		return provider.Versions[0].Name
+	}
+	Fail(fmt.Sprintf("provider %q is not configured in the e2e config", providerName))
+	return ""
 }
+
+var _ = JustAfterEach(func() {
+	if CurrentGinkgoTestDescription().Failed {
+		By("Re-checking controller log streams after a failed spec")
+		logcollector.CollectInfrastructureLogs(ctx, bootstrapClusterProxy, artifactFolder)
+	}
+})
+
+var _ = AfterSuite(func() {
+	By("Tearing down the management cluster")
+	// bootstrapClusterProxy.Dispose only releases resources this suite
+	// created for the proxy itself; for the "existing" backend the proxy
+	// wraps a developer's long-lived BYO cluster, so skip it there the
+	// same way mgmtCluster.Teardown is already a no-op for that backend.
+	if bootstrapClusterProxy != nil && managementBackend != "existing" {
+		bootstrapClusterProxy.Dispose(ctx)
+	}
+	if mgmtCluster != nil {
+		Expect(mgmtCluster.Teardown(ctx)).To(Succeed())
+	}
+})
@@ -0,0 +1,135 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha2"
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/addons"
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/images"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+)
+
+// defaultConfigCluster builds the clusterctl.ConfigClusterInput shared by
+// every spec in this suite, varying only by cluster/namespace name. The
+// flavor is picked by AZURE_IMAGE_RESOLVER: "marketplace" (default) maps
+// to cluster-template.yaml, "sig" to cluster-template-sig.yaml. Before
+// handing the flavor to clusterctl, the matching images.ImageResolver is
+// asked to resolve, so a flavor/variable mismatch (e.g. "sig" selected
+// without AZURE_IMAGE_SIG_* set) fails fast here instead of surfacing as
+// an opaque AzureMachine reconcile error later.
+func defaultConfigCluster(clusterName, namespace string) clusterctl.ConfigClusterInput {
+	resolver, err := images.NewResolver(e2eConfig.Variables)
+	Expect(err).NotTo(HaveOccurred(), "selecting an image resolver from AZURE_IMAGE_RESOLVER")
+
+	image, err := resolver.Resolve(ctx)
+	Expect(err).NotTo(HaveOccurred(), "resolving the machine image for cluster %q", clusterName)
+	assertResolvedImageMatchesFlavor(image, e2eConfig.Variables)
+
+	return clusterctl.ConfigClusterInput{
+		LogFolder:                filepath.Join(artifactFolder, "clusters", clusterName),
+		ClusterctlConfigPath:     clusterctlConfigPath,
+		KubeconfigPath:           bootstrapClusterProxy.GetKubeconfigPath(),
+		InfrastructureProvider:   "azure",
+		Flavor:                   flavorForResolver(e2eConfig.Variables["AZURE_IMAGE_RESOLVER"]),
+		Namespace:                namespace,
+		ClusterName:              clusterName,
+		KubernetesVersion:        e2eConfig.GetVariable("KUBERNETES_VERSION"),
+		ControlPlaneMachineCount: 1,
+		WorkerMachineCount:       2,
+	}
+}
+
+// assertResolvedImageMatchesFlavor fails the spec if the ImageResolver's
+// output disagrees with what the selected flavor's kustomize template
+// actually substitutes for the AZURE_IMAGE_* variables. This exists
+// because the real image parameterization happens via clusterctl's
+// env-substitution of those variables into the static template, not via
+// the Image this function returns; a resolver default that doesn't apply
+// to the raw template (e.g. SIGResolver defaulting an empty
+// AZURE_IMAGE_SIG_VERSION to "latest", while the template substitutes the
+// empty string verbatim) would otherwise silently diverge from what the
+// cluster actually boots.
+func assertResolvedImageMatchesFlavor(image *infrav1.Image, vars map[string]string) {
+	switch {
+	case image.Marketplace != nil:
+		Expect(image.Marketplace.Publisher).To(Equal(vars["AZURE_IMAGE_PUBLISHER"]))
+		Expect(image.Marketplace.Offer).To(Equal(vars["AZURE_IMAGE_OFFER"]))
+		Expect(image.Marketplace.SKU).To(Equal(vars["AZURE_IMAGE_SKU"]))
+		Expect(image.Marketplace.Version).To(Equal(vars["AZURE_IMAGE_VERSION"]),
+			"resolved marketplace image version must match AZURE_IMAGE_VERSION exactly, since cluster-template.yaml substitutes it verbatim")
+	case image.SharedGallery != nil:
+		Expect(image.SharedGallery.SubscriptionID).To(Equal(vars["AZURE_IMAGE_SIG_SUBSCRIPTION_ID"]))
+		Expect(image.SharedGallery.ResourceGroup).To(Equal(vars["AZURE_IMAGE_SIG_RESOURCE_GROUP"]))
+		Expect(image.SharedGallery.Gallery).To(Equal(vars["AZURE_IMAGE_SIG_GALLERY"]))
+		Expect(image.SharedGallery.Name).To(Equal(vars["AZURE_IMAGE_SIG_DEFINITION"]))
+		Expect(image.SharedGallery.Version).To(Equal(vars["AZURE_IMAGE_SIG_VERSION"]),
+			"resolved SIG image version must match AZURE_IMAGE_SIG_VERSION exactly, since cluster-template-sig.yaml substitutes it verbatim")
+	}
+}
+
+// flavorForResolver maps an AZURE_IMAGE_RESOLVER value to the clusterctl
+// flavor suffix that consumes the variables it resolves: "sig" maps to
+// "sig" (cluster-template-sig.yaml); every other value, including
+// "marketplace" and "", maps to "" (cluster-template.yaml).
+func flavorForResolver(resolver string) string {
+	switch images.ResolverKind(resolver) {
+	case images.ResolverSIG:
+		return "sig"
+	default:
+		return ""
+	}
+}
+
+// installWorkloadAddons installs Calico and cloud-provider-azure into the
+// named workload cluster and waits for both to roll out, so specs that
+// run after it can assume a healthy, Ready cluster.
+func installWorkloadAddons(namespace, clusterName string) {
+	workloadProxy := bootstrapClusterProxy.GetWorkloadCluster(ctx, namespace, clusterName)
+	kubeconfigPath := workloadProxy.GetKubeconfigPath()
+
+	By("Installing Calico")
+	Expect(addons.Calico.Install(ctx, kubeconfigPath)).To(Succeed())
+	addons.WaitForDaemonSetRollout(ctx, workloadProxy.GetClient(), addons.CalicoNamespace, addons.CalicoDaemonSet)
+
+	By("Installing cloud-provider-azure")
+	cloudProviderAzure := addons.CloudProviderAzure
+	cloudProviderAzure.Values = map[string]string{"infra.clusterName": clusterName}
+	Expect(cloudProviderAzure.Install(ctx, kubeconfigPath)).To(Succeed())
+	addons.WaitForDaemonSetRollout(ctx, workloadProxy.GetClient(), addons.CloudProviderNamespace, addons.CloudNodeManagerDaemonSet)
+
+	By("Waiting for all workload cluster nodes to be Ready")
+	addons.WaitForNodesReady(ctx, workloadProxy.GetClient())
+}
+
+// cleanupCluster deletes the named workload cluster and waits for the
+// underlying Azure resources to be garbage collected before returning.
+func cleanupCluster(namespace, clusterName string) {
+	By("Deleting the workload cluster " + clusterName)
+	clusterctl.DeleteClusterAndWait(ctx, clusterctl.DeleteClusterAndWaitInput{
+		Client:        bootstrapClusterProxy.GetClient(),
+		ClusterName:   clusterName,
+		Namespace:     namespace,
+		DeleteTimeout: e2eConfig.GetIntervals("default", "wait-delete-cluster")[0],
+	})
+}
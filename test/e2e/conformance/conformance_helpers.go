@@ -0,0 +1,213 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance runs the upstream Kubernetes conformance suite
+// (via kubetest) against a workload cluster that CAPZ has already
+// provisioned, and collects the resulting e2e.log/junit artifacts.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/cluster-api/test/framework"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+)
+
+// Profile selects which focus/skip regex and parallelism kubetest is
+// invoked with.
+type Profile string
+
+const (
+	// ProfileFast runs the subset of conformance tests that are safe to
+	// execute in parallel: Conformance, excluding Slow and Serial.
+	ProfileFast Profile = "fast"
+
+	// ProfileFull runs the entire conformance suite in two phases, serial
+	// then parallel, matching what the Kubernetes release process gates on.
+	ProfileFull Profile = "full"
+)
+
+// Build selects which Kubernetes artifact channel
+// downloadKubernetesTestBinaries fetches KubernetesVersion from.
+type Build string
+
+const (
+	// BuildStable resolves KubernetesVersion (e.g. "v1.16.2") against the
+	// released-builds bucket. This is the default.
+	BuildStable Build = "stable"
+
+	// BuildCI resolves KubernetesVersion (e.g.
+	// "v1.19.0-alpha.0.277+d5a49125bf7d3f") against the per-commit CI
+	// builds bucket, for exercising a Kubernetes build that hasn't shipped
+	// a release yet.
+	BuildCI Build = "ci"
+)
+
+// ConformanceSpecInput is the input for ConformanceSpec.
+type ConformanceSpecInput struct {
+	E2EConfig          *clusterctl.E2EConfig
+	ClusterProxy       framework.ClusterProxy
+	Namespace          string
+	ClusterName        string
+	KubernetesVersion  string
+	Build              Build
+	ArtifactsDirectory string
+	Profile            Profile
+	GinkgoNodes        int
+}
+
+// ConformanceSpec downloads the e2e.test/ginkgo binaries matching
+// input.KubernetesVersion, builds kubetest, then runs kubetest against
+// the workload cluster's kubeconfig, uploading e2e.log and junit_*.xml
+// under input.ArtifactsDirectory.
+func ConformanceSpec(ctx context.Context, inputGetter func() ConformanceSpecInput) {
+	input := inputGetter()
+	Expect(input.ClusterProxy).NotTo(BeNil(), "ClusterProxy is required for ConformanceSpec")
+	Expect(input.KubernetesVersion).NotTo(BeEmpty(), "KubernetesVersion is required for ConformanceSpec")
+
+	By("Getting the workload cluster kubeconfig")
+	workloadProxy := input.ClusterProxy.GetWorkloadCluster(ctx, input.Namespace, input.ClusterName)
+	kubeconfigPath := workloadProxy.GetKubeconfigPath()
+
+	By(fmt.Sprintf("Downloading kubetest binaries for %s", input.KubernetesVersion))
+	binDir, err := downloadKubernetesTestBinaries(ctx, input.KubernetesVersion, input.Build)
+	Expect(err).NotTo(HaveOccurred())
+
+	artifactsDir := filepath.Join(input.ArtifactsDirectory, "conformance", input.ClusterName)
+	Expect(os.MkdirAll(artifactsDir, 0755)).To(Succeed())
+
+	for _, phase := range kubetestPhases(input.Profile) {
+		By(fmt.Sprintf("Running kubetest (%s phase)", phase.name))
+		cmd := exec.CommandContext(ctx, filepath.Join(binDir, kubetestBinary), kubetestArgs(kubeconfigPath, artifactsDir, phase, input.GinkgoNodes)...)
+		cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", kubeconfigPath))
+		cmd.Stdout = GinkgoWriter
+		cmd.Stderr = GinkgoWriter
+		Expect(cmd.Run()).To(Succeed(), "kubetest %s phase failed, see e2e.log and junit_*.xml under %s", phase.name, artifactsDir)
+	}
+}
+
+type kubetestPhase struct {
+	name     string
+	focus    string
+	skip     string
+	parallel bool
+}
+
+// kubetestPhases returns the phases to run for a given profile: "fast"
+// is a single parallel phase, "full" runs serial tests first and then
+// the parallel-safe remainder.
+func kubetestPhases(profile Profile) []kubetestPhase {
+	switch profile {
+	case ProfileFull:
+		return []kubetestPhase{
+			{name: "serial", focus: `\[Serial\].*\[Conformance\]`, parallel: false},
+			{name: "parallel", focus: `\[Conformance\]`, skip: `\[Serial\]`, parallel: true},
+		}
+	case ProfileFast:
+		fallthrough
+	default:
+		return []kubetestPhase{
+			{name: "fast", focus: `\[Conformance\]`, skip: `\[Slow\]|\[Serial\]`, parallel: true},
+		}
+	}
+}
+
+// kubetestBinary is the name kubetest is cached under in binDir. It does
+// not ship in the kubernetes-test tarball (that only has e2e.test and
+// ginkgo) so it's installed separately; see downloadKubernetesTestBinaries.
+const kubetestBinary = "kubetest"
+
+// kubetestModuleVersion pins the k8s.io/test-infra commit kubetest is
+// installed from.
+const kubetestModuleVersion = "v0.0.0-20200521000000-000000000000"
+
+func kubetestArgs(kubeconfigPath, artifactsDir string, phase kubetestPhase, ginkgoNodes int) []string {
+	args := []string{
+		"--provider=skeleton",
+		"--test",
+		fmt.Sprintf("--kubeconfig=%s", kubeconfigPath),
+		fmt.Sprintf("--dump=%s", artifactsDir),
+		fmt.Sprintf("--test_args=--ginkgo.focus=%s --ginkgo.skip=%s --report-dir=%s", phase.focus, phase.skip, artifactsDir),
+	}
+	if phase.parallel {
+		args = append(args, fmt.Sprintf("--ginkgo-parallel=%d", ginkgoNodes))
+	}
+	return args
+}
+
+// testTarballURL returns the kubernetes-test linux-amd64 tarball URL for
+// version under the bucket build selects: BuildStable (the default)
+// resolves against the released-builds bucket, BuildCI against the
+// per-commit CI builds bucket.
+func testTarballURL(version string, build Build) string {
+	switch build {
+	case BuildCI:
+		return fmt.Sprintf("https://storage.googleapis.com/kubernetes-release-dev/ci/%s/kubernetes-test-linux-amd64.tar.gz", version)
+	case BuildStable:
+		fallthrough
+	default:
+		return fmt.Sprintf("https://storage.googleapis.com/kubernetes-release/release/%s/kubernetes-test-linux-amd64.tar.gz", version)
+	}
+}
+
+// downloadKubernetesTestBinaries fetches the kubernetes-test linux-amd64
+// tarball for version (stable or CI build, per the build parameter) and
+// extracts e2e.test and ginkgo into a per-version cache directory, then
+// installs kubetest itself into the same directory so kubetestArgs'
+// --provider/--test_args flags have a binary to run against. kubetest is
+// installed via `go install module@version` rather than a bare `go build`
+// of the package: this module never depends on k8s.io/test-infra, so a
+// plain `go build k8s.io/test-infra/kubetest` run from here fails with
+// "no required module provides package"; `go install ...@version` (Go
+// 1.16+) resolves and builds a pinned version of a tool without adding it
+// as a dependency. Returns the cache directory.
+func downloadKubernetesTestBinaries(ctx context.Context, version string, build Build) (string, error) {
+	cacheDir := filepath.Join(os.TempDir(), "capz-e2e", "kubetest", string(build), version)
+	if _, err := os.Stat(filepath.Join(cacheDir, kubetestBinary)); err == nil {
+		return cacheDir, nil
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", err
+	}
+
+	url := testTarballURL(version, build)
+	extract := exec.CommandContext(ctx, "bash", "-c",
+		fmt.Sprintf("curl -sSL %q | tar -xz --strip-components=3 -C %q kubernetes/test/bin/e2e.test kubernetes/test/bin/ginkgo", url, cacheDir))
+	extract.Stdout = GinkgoWriter
+	extract.Stderr = GinkgoWriter
+	if err := extract.Run(); err != nil {
+		return "", fmt.Errorf("downloading e2e.test/ginkgo for %s: %w", version, err)
+	}
+
+	install := exec.CommandContext(ctx, "go", "install", fmt.Sprintf("k8s.io/test-infra/kubetest@%s", kubetestModuleVersion))
+	install.Env = append(os.Environ(), "GO111MODULE=on", fmt.Sprintf("GOBIN=%s", cacheDir))
+	install.Stdout = GinkgoWriter
+	install.Stderr = GinkgoWriter
+	if err := install.Run(); err != nil {
+		return "", fmt.Errorf("installing kubetest@%s: %w", kubetestModuleVersion, err)
+	}
+
+	return cacheDir, nil
+}
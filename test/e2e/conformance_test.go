@@ -0,0 +1,78 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e_test
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/conformance"
+	"sigs.k8s.io/cluster-api-provider-azure/test/e2e/framework/addons"
+	"sigs.k8s.io/cluster-api/test/framework/clusterctl"
+)
+
+func init() {
+	// Seed explicitly: the default source is otherwise deterministic, so
+	// every run would generate the identical cluster name and collide
+	// with a leftover cluster/resource-group from a prior failed run.
+	rand.Seed(time.Now().UnixNano())
+}
+
+var _ = Describe("Conformance Tests", func() {
+	var namespace, clusterName string
+
+	BeforeEach(func() {
+		namespace = "default"
+		clusterName = fmt.Sprintf("capz-e2e-conformance-%x", rand.Int31())
+
+		By("Provisioning a workload cluster for conformance")
+		clusterctl.ApplyClusterTemplateAndWait(ctx, clusterctl.ApplyClusterTemplateAndWaitInput{
+			ClusterProxy:                 bootstrapClusterProxy,
+			ConfigCluster:                defaultConfigCluster(clusterName, namespace),
+			WaitForClusterIntervals:      e2eConfig.GetIntervals("default", "wait-cluster"),
+			WaitForControlPlaneIntervals: e2eConfig.GetIntervals("default", "wait-control-plane"),
+			WaitForMachineDeployments:    e2eConfig.GetIntervals("default", "wait-worker-nodes"),
+		})
+
+		installWorkloadAddons(namespace, clusterName)
+	})
+
+	AfterEach(func() {
+		cleanupCluster(namespace, clusterName)
+	})
+
+	It("should run the fast conformance profile", func() {
+		conformance.ConformanceSpec(ctx, func() conformance.ConformanceSpecInput {
+			return conformance.ConformanceSpecInput{
+				E2EConfig:          e2eConfig,
+				ClusterProxy:       bootstrapClusterProxy,
+				Namespace:          namespace,
+				ClusterName:        clusterName,
+				KubernetesVersion:  e2eConfig.GetVariable("KUBERNETES_VERSION"),
+				Build:              conformance.BuildStable,
+				ArtifactsDirectory: artifactFolder,
+				Profile:            conformance.ProfileFast,
+				GinkgoNodes:        4,
+			}
+		})
+	})
+})
@@ -0,0 +1,93 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package addons installs workload-cluster addons (CNI,
+// cloud-provider-azure) via Helm, so conformance and workload specs can
+// assume a healthy cluster instead of hand-rolling kubectl apply steps.
+// It mirrors the shape of the CAPI Helm addon-provider's HelmChartProxy,
+// but lives entirely in-repo since CAPZ doesn't depend on that provider.
+package addons
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/strvals"
+)
+
+// HelmChartProxy declaratively describes a Helm release to install into
+// a workload cluster.
+type HelmChartProxy struct {
+	// RepoURL is the Helm repository the chart is fetched from.
+	RepoURL string
+	// ChartName is the chart's name within RepoURL.
+	ChartName string
+	// Version pins the chart version; empty means latest.
+	Version string
+	// ReleaseName is the Helm release name.
+	ReleaseName string
+	// Namespace is created if missing and receives the release.
+	Namespace string
+	// Values are passed to the chart as --set-style overrides.
+	Values map[string]string
+}
+
+// Install renders and installs the chart into the cluster addressed by
+// kubeconfigPath, creating Namespace if it doesn't already exist.
+func (p HelmChartProxy) Install(ctx context.Context, kubeconfigPath string) error {
+	settings := cli.New()
+	settings.KubeConfig = kubeconfigPath
+
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), p.Namespace, "secret", func(format string, v ...interface{}) {}); err != nil {
+		return fmt.Errorf("initializing helm action config for %s: %w", p.ReleaseName, err)
+	}
+
+	install := action.NewInstall(actionConfig)
+	install.ReleaseName = p.ReleaseName
+	install.Namespace = p.Namespace
+	install.CreateNamespace = true
+	install.Version = p.Version
+	install.ChartPathOptions.RepoURL = p.RepoURL
+
+	chartPath, err := install.ChartPathOptions.LocateChart(p.ChartName, settings)
+	if err != nil {
+		return fmt.Errorf("locating chart %s/%s: %w", p.RepoURL, p.ChartName, err)
+	}
+
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return fmt.Errorf("loading chart %s: %w", chartPath, err)
+	}
+
+	values := map[string]interface{}{}
+	for k, v := range p.Values {
+		if err := strvals.ParseInto(k+"="+v, values); err != nil {
+			return fmt.Errorf("parsing value %s for %s: %w", k, p.ReleaseName, err)
+		}
+	}
+
+	_, err = install.RunWithContext(ctx, chart, values)
+	if err != nil {
+		return fmt.Errorf("installing release %s: %w", p.ReleaseName, err)
+	}
+	return nil
+}
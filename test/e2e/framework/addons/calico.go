@@ -0,0 +1,36 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addons
+
+// Calico is the built-in HelmChartProxy spec for the Calico CNI,
+// installed before workload checks so pods can actually schedule.
+var Calico = HelmChartProxy{
+	RepoURL:     "https://projectcalico.docs.tigera.io/charts",
+	ChartName:   "tigera-operator",
+	Version:     "v3.16.9",
+	ReleaseName: "calico",
+	Namespace:   "tigera-operator",
+}
+
+// CalicoDaemonSet identifies the DaemonSet WaitForDaemonSetRollout
+// should watch after installing Calico.
+const (
+	CalicoNamespace = "calico-system"
+	CalicoDaemonSet = "calico-node"
+)
@@ -0,0 +1,40 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addons
+
+// CloudProviderAzure is the built-in HelmChartProxy spec for
+// cloud-provider-azure, which every workload cluster needs in order for
+// Nodes to leave the NotReady/uninitialized state.
+var CloudProviderAzure = HelmChartProxy{
+	RepoURL:     "https://raw.githubusercontent.com/kubernetes-sigs/cloud-provider-azure/master/helm/repo",
+	ChartName:   "cloud-provider-azure",
+	ReleaseName: "cloud-provider-azure",
+	Namespace:   "kube-system",
+	Values: map[string]string{
+		"infra.clusterName": "", // filled in by the caller with the workload cluster name
+	},
+}
+
+// CloudNodeManagerDaemonSet identifies the DaemonSet
+// WaitForDaemonSetRollout should watch after installing
+// cloud-provider-azure.
+const (
+	CloudProviderNamespace    = "kube-system"
+	CloudNodeManagerDaemonSet = "cloud-node-manager"
+)
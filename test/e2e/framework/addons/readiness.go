@@ -0,0 +1,75 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addons
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WaitForDaemonSetRollout blocks until every node's daemon pod for the
+// named DaemonSet is ready.
+func WaitForDaemonSetRollout(ctx context.Context, c client.Client, namespace, name string, intervals ...interface{}) {
+	Eventually(func() (bool, error) {
+		ds := &appsv1.DaemonSet{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ds); err != nil {
+			return false, err
+		}
+		return ds.Status.NumberReady > 0 && ds.Status.NumberReady == ds.Status.DesiredNumberScheduled, nil
+	}, intervalsOrDefault(intervals)...).Should(BeTrue(), "DaemonSet %s/%s never became ready", namespace, name)
+}
+
+// WaitForNodesReady blocks until every Node in the workload cluster
+// reports a True Ready condition.
+func WaitForNodesReady(ctx context.Context, c client.Client, intervals ...interface{}) {
+	Eventually(func() (bool, error) {
+		nodes := &corev1.NodeList{}
+		if err := c.List(ctx, nodes); err != nil {
+			return false, err
+		}
+		if len(nodes.Items) == 0 {
+			return false, nil
+		}
+		for _, node := range nodes.Items {
+			ready := false
+			for _, cond := range node.Status.Conditions {
+				if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+					ready = true
+				}
+			}
+			if !ready {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, intervalsOrDefault(intervals)...).Should(BeTrue(), "not all nodes became Ready")
+}
+
+func intervalsOrDefault(intervals []interface{}) []interface{} {
+	if len(intervals) > 0 {
+		return intervals
+	}
+	return []interface{}{10 * time.Minute, 10 * time.Second}
+}
@@ -0,0 +1,176 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logcollector streams controller-manager logs out of a running
+// management cluster into per-pod, per-container files under $ARTIFACTS,
+// redacting Azure secrets as it goes. It replaces the old watchDeployment
+// helper, which was disabled entirely because capi-controller-manager
+// prints out azure.json.
+package logcollector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// reconnectBackoff is how long a stream waits before retrying after the
+// apiserver drops the log connection (pod restart, network blip, etc).
+const reconnectBackoff = 5 * time.Second
+
+// Deployment streams logs for every container of every pod backing a
+// Deployment, restarting the stream whenever pods are added, replaced,
+// or restarted, until ctx is cancelled.
+type Deployment struct {
+	Clientset *kubernetes.Clientset
+	Namespace string
+	Name      string
+	LogDir    string
+	Redactor  Redactor
+}
+
+// Stream discovers deployment.Namespace/Name's pods via an informer and
+// streams each container's logs to $LogDir/<pod>/<container>.log. It
+// blocks until ctx is done.
+func (d *Deployment) Stream(ctx context.Context) error {
+	deployment, err := d.Clientset.AppsV1().Deployments(d.Namespace).Get(d.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("getting deployment %s/%s: %w", d.Namespace, d.Name, err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("parsing selector for deployment %s/%s: %w", d.Namespace, d.Name, err)
+	}
+
+	stopCh := ctx.Done()
+	active := map[string]context.CancelFunc{}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(d.Clientset, 0,
+		informers.WithNamespace(d.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = selector.String()
+		}),
+	)
+	podInformer := factory.Core().V1().Pods().Informer()
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			pod := obj.(*corev1.Pod)
+			d.startPod(ctx, pod, active)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			pod := obj.(*corev1.Pod)
+			d.startPod(ctx, pod, active)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				if cancel, found := active[pod.Name]; found {
+					cancel()
+					delete(active, pod.Name)
+				}
+			}
+		},
+	})
+
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+	<-stopCh
+	return nil
+}
+
+func (d *Deployment) startPod(ctx context.Context, pod *corev1.Pod, active map[string]context.CancelFunc) {
+	if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+		return
+	}
+	if _, running := active[pod.Name]; running {
+		return
+	}
+	podCtx, cancel := context.WithCancel(ctx)
+	active[pod.Name] = cancel
+
+	for _, container := range pod.Spec.Containers {
+		go d.streamContainer(podCtx, pod.Name, container.Name)
+	}
+}
+
+// streamContainer tails a single container's logs, retrying with
+// reconnectBackoff whenever the connection drops, until ctx is done.
+func (d *Deployment) streamContainer(ctx context.Context, podName, containerName string) {
+	logFile := filepath.Join(d.LogDir, podName, containerName+".log")
+	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+		klog.Errorf("logcollector: creating log dir for %s/%s: %v", podName, containerName, err)
+		return
+	}
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		klog.Errorf("logcollector: opening log file for %s/%s: %v", podName, containerName, err)
+		return
+	}
+	defer f.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		opts := &corev1.PodLogOptions{Container: containerName, Follow: true}
+		stream, err := d.Clientset.CoreV1().Pods(d.Namespace).GetLogs(podName, opts).Stream()
+		if err != nil {
+			klog.Warningf("logcollector: opening log stream for %s/%s: %v, retrying in %s", podName, containerName, err, reconnectBackoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconnectBackoff):
+				continue
+			}
+		}
+		d.copyRedacted(f, stream)
+		stream.Close()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectBackoff):
+		}
+	}
+}
+
+func (d *Deployment) copyRedacted(w io.Writer, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if d.Redactor != nil {
+			line = d.Redactor.Redact(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
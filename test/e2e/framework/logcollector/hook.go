@@ -0,0 +1,93 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logcollector
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"sigs.k8s.io/cluster-api/test/framework"
+)
+
+var (
+	startedMu sync.Mutex
+	started   = map[string]bool{}
+)
+
+// deployments are the controller-manager Deployments CAPZ streams logs
+// for by default; capi-controller-manager is included now that its
+// azure.json output is redacted rather than simply not collected.
+var deployments = []struct {
+	namespace string
+	name      string
+}{
+	{namespace: "capi-system", name: "capi-controller-manager"},
+	{namespace: "cabpk-system", name: "cabpk-controller-manager"},
+	{namespace: "capz-system", name: "capz-controller-manager"},
+}
+
+// CollectInfrastructureLogs starts (or restarts) log streaming for every
+// known controller-manager Deployment in mgmt, writing redacted,
+// per-pod/per-container files under artifactDir/logs. It is safe to call
+// from AfterSuite as well as after every failed spec: a Deployment whose
+// streaming goroutines are already running is left alone.
+func CollectInfrastructureLogs(ctx context.Context, mgmt framework.ClusterProxy, artifactDir string) {
+	clientset, err := kubernetes.NewForConfig(mgmt.GetRESTConfig())
+	if err != nil {
+		klog.Errorf("logcollector: building clientset: %v", err)
+		return
+	}
+
+	logDir := filepath.Join(artifactDir, "logs")
+	for _, dep := range deployments {
+		key := dep.namespace + "/" + dep.name
+		startedMu.Lock()
+		alreadyStarted := started[key]
+		started[key] = true
+		startedMu.Unlock()
+		if alreadyStarted {
+			continue
+		}
+
+		collector := &Deployment{
+			Clientset: clientset,
+			Namespace: dep.namespace,
+			Name:      dep.name,
+			LogDir:    filepath.Join(logDir, dep.namespace, dep.name),
+			Redactor:  DefaultRedactors(),
+		}
+		go func(c *Deployment, key string) {
+			if err := c.Stream(ctx); err != nil {
+				klog.Warningf("logcollector: streaming %s/%s: %v", c.Namespace, c.Name, err)
+				// Stream exited without ever collecting anything (e.g. the
+				// Deployment didn't exist yet when this call ran) - clear
+				// started so the next CollectInfrastructureLogs call (from
+				// JustAfterEach on a failed spec) retries it instead of
+				// treating this Deployment as already handled forever.
+				startedMu.Lock()
+				delete(started, key)
+				startedMu.Unlock()
+			}
+		}(collector, key)
+	}
+}
@@ -0,0 +1,69 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logcollector
+
+import "regexp"
+
+// Redactor scrubs sensitive substrings out of a single log line before it
+// is written to disk.
+type Redactor interface {
+	Redact(line string) string
+}
+
+// RedactorChain applies a sequence of Redactors in order.
+type RedactorChain []Redactor
+
+// Redact runs line through every Redactor in the chain.
+func (c RedactorChain) Redact(line string) string {
+	for _, r := range c {
+		line = r.Redact(line)
+	}
+	return line
+}
+
+// RedactorFunc adapts a plain function to the Redactor interface.
+type RedactorFunc func(line string) string
+
+// Redact implements Redactor.
+func (f RedactorFunc) Redact(line string) string { return f(line) }
+
+var (
+	clientSecretPattern = regexp.MustCompile(`(?i)("?clientSecret"?\s*[:=]\s*")[^"]+(")`)
+	subscriptionIDRegex = regexp.MustCompile(`(?i)("?subscriptionId"?\s*[:=]\s*")[0-9a-fA-F-]{36}(")`)
+	azureJSONBlobRegex  = regexp.MustCompile(`[A-Za-z0-9+/]{80,}={0,2}`)
+)
+
+// DefaultRedactors returns the redactor chain CAPZ uses by default to
+// make it safe to stream capz-controller-manager and
+// capi-controller-manager logs: it strips client secrets, subscription
+// IDs, and the base64-encoded azure.json blobs the cloud-provider
+// machinery occasionally logs verbatim.
+func DefaultRedactors() RedactorChain {
+	return RedactorChain{
+		RedactorFunc(func(line string) string {
+			return clientSecretPattern.ReplaceAllString(line, "${1}REDACTED${2}")
+		}),
+		RedactorFunc(func(line string) string {
+			return subscriptionIDRegex.ReplaceAllString(line, "${1}REDACTED${2}")
+		}),
+		RedactorFunc(func(line string) string {
+			return azureJSONBlobRegex.ReplaceAllString(line, "REDACTED-BASE64-BLOB")
+		}),
+	}
+}
@@ -0,0 +1,89 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package existing implements management.Cluster on top of a long-lived,
+// bring-your-own management cluster (e.g. a developer's AKS cluster)
+// resolved from --kubeconfig/KUBECONFIG, so the suite can be iterated on
+// without recreating a kind cluster on every run.
+package existing
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	// side-effect import registering the azure auth plugin, needed to
+	// resolve kubeconfigs pointing at AKS clusters that use exec/azure
+	// auth providers.
+	_ "k8s.io/client-go/plugin/pkg/client/auth/azure"
+)
+
+// kubeconfigFlagName is the flag NewCluster reads the management
+// cluster's kubeconfig path from. It's registered from init by
+// registerKubeconfigFlag, which skips registration when a flag of that
+// name already exists, rather than with an unconditional package-level
+// flag.String: controller-runtime's config package also registers
+// "--kubeconfig" when linked into the same binary, and a second
+// flag.String under the same name panics at init with "flag redefined:
+// kubeconfig". A flag registered by a package initialized after this one
+// is not checked.
+const kubeconfigFlagName = "kubeconfig"
+
+func init() {
+	registerKubeconfigFlag()
+}
+
+func registerKubeconfigFlag() {
+	if flag.Lookup(kubeconfigFlagName) == nil {
+		flag.String(kubeconfigFlagName, "", "path to the kubeconfig of an existing management cluster")
+	}
+}
+
+// Cluster is a bring-your-own management cluster that the suite neither
+// creates nor tears down.
+type Cluster struct {
+	kubeconfigPath string
+}
+
+// NewCluster resolves an existing management cluster's kubeconfig, in
+// order of precedence: the --kubeconfig flag, then the KUBECONFIG
+// environment variable, then ~/.kube/config.
+func NewCluster() (*Cluster, error) {
+	if f := flag.Lookup(kubeconfigFlagName); f != nil && f.Value.String() != "" {
+		return &Cluster{kubeconfigPath: f.Value.String()}, nil
+	}
+	if path, ok := os.LookupEnv("KUBECONFIG"); ok && path != "" {
+		return &Cluster{kubeconfigPath: path}, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cluster{kubeconfigPath: home + "/.kube/config"}, nil
+}
+
+// GetKubeconfigPath returns the resolved kubeconfig path.
+func (c *Cluster) GetKubeconfigPath() string {
+	return c.kubeconfigPath
+}
+
+// Teardown is a no-op: the suite does not own an existing cluster's
+// lifecycle.
+func (c *Cluster) Teardown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kind implements management.Cluster on top of a disposable kind
+// cluster, created and loaded with images via the upstream CAPI
+// bootstrap helpers.
+package kind
+
+import (
+	"context"
+
+	"sigs.k8s.io/cluster-api/test/framework/bootstrap"
+)
+
+// Cluster is a disposable kind management cluster.
+type Cluster struct {
+	provider bootstrap.ClusterProvider
+}
+
+// NewCluster creates a kind cluster named name and loads images into it.
+func NewCluster(ctx context.Context, name string, images []bootstrap.ImageEntry) (*Cluster, error) {
+	provider := bootstrap.CreateKindBootstrapClusterAndLoadImages(ctx, bootstrap.CreateKindBootstrapClusterAndLoadImagesInput{
+		Name:   name,
+		Images: images,
+	})
+	return &Cluster{provider: provider}, nil
+}
+
+// GetKubeconfigPath returns the kubeconfig for the kind cluster.
+func (c *Cluster) GetKubeconfigPath() string {
+	return c.provider.GetKubeconfigPath()
+}
+
+// Teardown deletes the kind cluster.
+func (c *Cluster) Teardown(ctx context.Context) error {
+	c.provider.Dispose(ctx)
+	return nil
+}
@@ -0,0 +1,37 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package management abstracts over how the e2e suite obtains a
+// management cluster to install CAPI, CABPK and CAPZ into, so the same
+// specs run unmodified whether that cluster is a disposable kind
+// cluster, a developer's long-lived cluster, or (in the future) a
+// managed AKS cluster.
+package management
+
+import "context"
+
+// Cluster is implemented by every management cluster backend the e2e
+// suite knows how to talk to.
+type Cluster interface {
+	// GetKubeconfigPath returns the path to a kubeconfig for the cluster.
+	GetKubeconfigPath() string
+
+	// Teardown releases the cluster's resources. Backends that don't own
+	// the cluster's lifecycle (e.g. existing) treat this as a no-op.
+	Teardown(ctx context.Context) error
+}
@@ -0,0 +1,81 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package images resolves the VM image an e2e flavor's AzureMachine(s)
+// should boot from, so the same specs can validate both official CNCF
+// marketplace images and custom images published to a Shared Image
+// Gallery (or Azure Community Gallery) without forking the suite.
+package images
+
+import (
+	"context"
+	"fmt"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha2"
+)
+
+// ImageResolver produces the infrav1.Image an AzureMachineTemplate
+// generator should use for a given flavor.
+type ImageResolver interface {
+	Resolve(ctx context.Context) (*infrav1.Image, error)
+}
+
+// ResolverKind names an ImageResolver implementation, as selected by the
+// AZURE_IMAGE_RESOLVER e2e config variable.
+type ResolverKind string
+
+const (
+	// ResolverMarketplace resolves to a published CNCF marketplace image.
+	// This is the suite's historical, default behavior.
+	ResolverMarketplace ResolverKind = "marketplace"
+
+	// ResolverSIG resolves to an image published to a Shared Image
+	// Gallery or Azure Community Gallery, e.g. one built by
+	// image-builder for a distro/OS combination marketplace doesn't
+	// publish (Flatcar, Windows, Ubuntu 20.04).
+	ResolverSIG ResolverKind = "sig"
+)
+
+// Variables carries the subset of an e2eConfig's variables the resolvers
+// in this package need, keyed the same as they appear in
+// test/e2e/config/azure.yaml.
+type Variables map[string]string
+
+// NewResolver returns the ImageResolver named by vars["AZURE_IMAGE_RESOLVER"],
+// defaulting to ResolverMarketplace when unset.
+func NewResolver(vars Variables) (ImageResolver, error) {
+	switch kind := ResolverKind(vars["AZURE_IMAGE_RESOLVER"]); kind {
+	case "", ResolverMarketplace:
+		return &MarketplaceResolver{
+			Publisher: vars["AZURE_IMAGE_PUBLISHER"],
+			Offer:     vars["AZURE_IMAGE_OFFER"],
+			SKU:       vars["AZURE_IMAGE_SKU"],
+			Version:   vars["AZURE_IMAGE_VERSION"],
+		}, nil
+	case ResolverSIG:
+		return &SIGResolver{
+			SubscriptionID:  vars["AZURE_IMAGE_SIG_SUBSCRIPTION_ID"],
+			ResourceGroup:   vars["AZURE_IMAGE_SIG_RESOURCE_GROUP"],
+			Gallery:         vars["AZURE_IMAGE_SIG_GALLERY"],
+			ImageDefinition: vars["AZURE_IMAGE_SIG_DEFINITION"],
+			ImageVersion:    vars["AZURE_IMAGE_SIG_VERSION"],
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported AZURE_IMAGE_RESOLVER %q, must be one of: marketplace, sig", kind)
+	}
+}
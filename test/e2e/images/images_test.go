@@ -0,0 +1,106 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewResolverMarketplace(t *testing.T) {
+	vars := Variables{
+		"AZURE_IMAGE_PUBLISHER": "cncf-upstream",
+		"AZURE_IMAGE_OFFER":     "capi",
+		"AZURE_IMAGE_SKU":       "k8s-1dot16-ubuntu-1804",
+		"AZURE_IMAGE_VERSION":   "latest",
+	}
+
+	resolver, err := NewResolver(vars)
+	if err != nil {
+		t.Fatalf("NewResolver returned an error: %v", err)
+	}
+	if _, ok := resolver.(*MarketplaceResolver); !ok {
+		t.Fatalf("NewResolver with no AZURE_IMAGE_RESOLVER returned %T, want *MarketplaceResolver", resolver)
+	}
+
+	image, err := resolver.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if image.Marketplace == nil {
+		t.Fatal("Resolve returned an image with a nil Marketplace field")
+	}
+	if image.Marketplace.Offer != "capi" {
+		t.Errorf("Marketplace.Offer = %q, want %q", image.Marketplace.Offer, "capi")
+	}
+}
+
+func TestNewResolverSIG(t *testing.T) {
+	vars := Variables{
+		"AZURE_IMAGE_RESOLVER":            "sig",
+		"AZURE_IMAGE_SIG_SUBSCRIPTION_ID": "11111111-1111-1111-1111-111111111111",
+		"AZURE_IMAGE_SIG_RESOURCE_GROUP":  "capz-gallery-rg",
+		"AZURE_IMAGE_SIG_GALLERY":         "capzGallery",
+		"AZURE_IMAGE_SIG_DEFINITION":      "ubuntu-2004",
+		"AZURE_IMAGE_SIG_VERSION":         "1.0.0",
+	}
+
+	resolver, err := NewResolver(vars)
+	if err != nil {
+		t.Fatalf("NewResolver returned an error: %v", err)
+	}
+	if _, ok := resolver.(*SIGResolver); !ok {
+		t.Fatalf("NewResolver with AZURE_IMAGE_RESOLVER=sig returned %T, want *SIGResolver", resolver)
+	}
+
+	image, err := resolver.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if image.SharedGallery == nil {
+		t.Fatal("Resolve returned an image with a nil SharedGallery field")
+	}
+	if image.SharedGallery.Name != "ubuntu-2004" {
+		t.Errorf("SharedGallery.Name = %q, want %q", image.SharedGallery.Name, "ubuntu-2004")
+	}
+	if image.SharedGallery.Version != "1.0.0" {
+		t.Errorf("SharedGallery.Version = %q, want %q", image.SharedGallery.Version, "1.0.0")
+	}
+}
+
+func TestNewResolverSIGMissingVariables(t *testing.T) {
+	vars := Variables{"AZURE_IMAGE_RESOLVER": "sig"}
+
+	resolver, err := NewResolver(vars)
+	if err != nil {
+		t.Fatalf("NewResolver returned an error: %v", err)
+	}
+
+	if _, err := resolver.Resolve(context.Background()); err == nil {
+		t.Fatal("Resolve succeeded with no AZURE_IMAGE_SIG_* variables set, want an error")
+	}
+}
+
+func TestNewResolverUnknownKind(t *testing.T) {
+	vars := Variables{"AZURE_IMAGE_RESOLVER": "bogus"}
+
+	if _, err := NewResolver(vars); err == nil {
+		t.Fatal("NewResolver succeeded with an unknown AZURE_IMAGE_RESOLVER, want an error")
+	}
+}
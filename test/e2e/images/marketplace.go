@@ -0,0 +1,55 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha2"
+)
+
+// MarketplaceResolver resolves a published CNCF marketplace image. This
+// is the suite's historical behavior, previously hard-coded as the
+// imageOffer/imagePublisher/imageSKU/imageVersion constants.
+type MarketplaceResolver struct {
+	Publisher string
+	Offer     string
+	SKU       string
+	Version   string
+}
+
+// Resolve implements ImageResolver.
+func (r *MarketplaceResolver) Resolve(ctx context.Context) (*infrav1.Image, error) {
+	if r.Publisher == "" || r.Offer == "" || r.SKU == "" {
+		return nil, fmt.Errorf("marketplace image resolver requires publisher, offer and sku")
+	}
+	version := r.Version
+	if version == "" {
+		version = "latest"
+	}
+	return &infrav1.Image{
+		Marketplace: &infrav1.AzureMarketplaceImage{
+			Publisher: r.Publisher,
+			Offer:     r.Offer,
+			SKU:       r.SKU,
+			Version:   version,
+		},
+	}, nil
+}
@@ -0,0 +1,58 @@
+// +build e2e
+
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+
+	infrav1 "sigs.k8s.io/cluster-api-provider-azure/api/v1alpha2"
+)
+
+// SIGResolver resolves an image published to a Shared Image Gallery or
+// Azure Community Gallery, identified by
+// subscriptionID/resourceGroup/gallery/imageDefinition[/version]. An
+// empty ImageVersion resolves to the gallery's latest image version.
+type SIGResolver struct {
+	SubscriptionID  string
+	ResourceGroup   string
+	Gallery         string
+	ImageDefinition string
+	ImageVersion    string
+}
+
+// Resolve implements ImageResolver.
+func (r *SIGResolver) Resolve(ctx context.Context) (*infrav1.Image, error) {
+	if r.SubscriptionID == "" || r.ResourceGroup == "" || r.Gallery == "" || r.ImageDefinition == "" {
+		return nil, fmt.Errorf("SIG image resolver requires subscriptionID, resourceGroup, gallery and imageDefinition")
+	}
+	version := r.ImageVersion
+	if version == "" {
+		version = "latest"
+	}
+	return &infrav1.Image{
+		SharedGallery: &infrav1.AzureSharedGalleryImage{
+			SubscriptionID: r.SubscriptionID,
+			ResourceGroup:  r.ResourceGroup,
+			Gallery:        r.Gallery,
+			Name:           r.ImageDefinition,
+			Version:        version,
+		},
+	}, nil
+}